@@ -3,10 +3,13 @@ package main
 import (
 	"container/list"
 	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"math/big"
 	"math/rand"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
@@ -22,7 +25,9 @@ type Cell struct {
 	y         int8
 }
 
+// Minesweeper is safe for concurrent use: moves take a write lock and reads take a read lock
 type Minesweeper struct {
+	mu     sync.RWMutex
 	field  [][]Cell
 	width  int
 	height int
@@ -38,47 +43,142 @@ func (c Cell) IsBomb() bool {
 	return c.isBomb
 }
 
+// Symmetry describes how mines are mirrored across the field during generation
+type Symmetry int
+
+const (
+	// SymmetryNone places every mine independently
+	SymmetryNone Symmetry = iota
+	// SymmetryHorizontal mirrors the left half of the field onto the right half
+	SymmetryHorizontal
+	// SymmetryVertical mirrors the top half of the field onto the bottom half
+	SymmetryVertical
+	// SymmetryRotational makes the field look the same after a 180 degree turn
+	SymmetryRotational
+)
+
+// ParseSymmetry converts a symmetry name as given on the command line to a Symmetry
+func ParseSymmetry(name string) (error, Symmetry) {
+	switch name {
+	case "", "none":
+		return nil, SymmetryNone
+	case "horizontal":
+		return nil, SymmetryHorizontal
+	case "vertical":
+		return nil, SymmetryVertical
+	case "rotational":
+		return nil, SymmetryRotational
+	}
+	return fmt.Errorf("Unknown symmetry %q", name), SymmetryNone
+}
+
+// mirror returns the cell that (x, y) maps to under symmetry s
+func (s Symmetry) mirror(x, y, width, height int) (int, int) {
+	switch s {
+	case SymmetryHorizontal:
+		return width - 1 - x, y
+	case SymmetryVertical:
+		return x, height - 1 - y
+	case SymmetryRotational:
+		return width - 1 - x, height - 1 - y
+	}
+	return x, y
+}
+
+// chooseNumSingles picks how many of the bombs go to single-cell orbits when
+// numBombs bombs are spread over numSingles single orbits and numPairs pair orbits.
+// Each choice k is weighted by the number of layouts it allows, C(numSingles, k) * C(numPairs, (numBombs-k)/2),
+// so every symmetric layout is equally likely and the axis cells aren't given away by the parity of numBombs
+func chooseNumSingles(numBombs, numSingles, numPairs int) (error, int) {
+	weights := make([]*big.Int, numSingles+1)
+	total := new(big.Int)
+
+	for k := numBombs % 2; k <= numSingles && k <= numBombs; k += 2 {
+		if (numBombs-k)/2 > numPairs {
+			continue
+		}
+		weights[k] = new(big.Int).Binomial(int64(numSingles), int64(k))
+		weights[k].Mul(weights[k], new(big.Int).Binomial(int64(numPairs), int64((numBombs-k)/2)))
+		total.Add(total, weights[k])
+	}
+
+	if total.Sign() == 0 {
+		return fmt.Errorf("Can't place %d bombs with this symmetry", numBombs), 0
+	}
+
+	// draw a random layout index and find which k it falls into
+	r := new(big.Int).Rand(rand.New(rand.NewSource(rand.Int63())), total)
+	for k, weight := range weights {
+		if weight == nil {
+			continue
+		}
+		if r.Cmp(weight) < 0 {
+			return nil, k
+		}
+		r.Sub(r, weight)
+	}
+
+	// unreachable: r is always smaller than the sum of all weights
+	return errors.New("Failed to choose bomb layout"), 0
+}
+
 // NewMinesweeper creates a new minesweeper field.
 func NewMinesweeper(width, height, numBombs int8) (error, *Minesweeper) {
+	return NewSymmetricMinesweeper(width, height, numBombs, SymmetryNone)
+}
+
+// NewSymmetricMinesweeper creates a new minesweeper field whose mines are laid out with the given symmetry.
+// The field always contains exactly numBombs mines, so some counts are impossible for some symmetries
+// (e.g. an odd count on an even-sized field with rotational symmetry)
+func NewSymmetricMinesweeper(width, height, numBombs int8, symmetry Symmetry) (error, *Minesweeper) {
 	rand.Seed(time.Now().UnixNano())
 	if width > 32 || height > 32 {
 		return errors.New("Width or height can't be > 32"), nil
 	}
 
-	if numBombs > (width * height) {
+	if int(numBombs) > int(width)*int(height) {
 		return errors.New("Too many bombs"), nil
 	}
 
+	if numBombs < 0 {
+		return errors.New("Number of bombs can't be negative"), nil
+	}
+
 	field := make([][]Cell, height)
 
 	for i := range field {
 		field[i] = make([]Cell, width)
 	}
 
-	// generate bombs at random positions
-	// consider all bombs are placed at the start
-	// for each bomb we will swap it with random element
-	for i := 0; i < int(numBombs+1); i++ {
-		// generate a second cell index to swap with
-		i2 := i + rand.Intn(int(width)*int(height)-i)
-
-		// convert sequential index to row number
-		row1 := i / int(width)
-
-		// if indices are different, then we should place bomb at
-		// newly generated index
-		if i != i2 {
-			// convert sequential index to row and col numbers
-			row2 := i2 / int(width)
-			col2 := (i2 - row2*int(width)) % int(width)
-
-			field[row2][col2].isBomb = true
-		} else {
-			// convert sequential index to col number
-			col1 := (i - row1*int(width)) % int(width)
-
-			// else, leave the bomb at the current index
-			field[row1][col1].isBomb = true
+	// group cells into orbits: sets of cells that map onto each other under the symmetry.
+	// Every symmetry we support is an involution, so an orbit is either a single cell
+	// lying on the axis (or centre) or a pair of mirrored cells
+	var singles, pairs [][2]int
+	for i := 0; i < int(width)*int(height); i++ {
+		x, y := i%int(width), i/int(width)
+		mx, my := symmetry.mirror(x, y, int(width), int(height))
+		i2 := my*int(width) + mx
+
+		if i2 == i {
+			singles = append(singles, [2]int{i, i})
+		} else if i < i2 {
+			pairs = append(pairs, [2]int{i, i2})
+		}
+	}
+
+	err, numSingles := chooseNumSingles(int(numBombs), len(singles), len(pairs))
+	if err != nil {
+		return err, nil
+	}
+	numPairs := (int(numBombs) - numSingles) / 2
+
+	// pick random orbits and place bombs in all of their cells
+	rand.Shuffle(len(pairs), func(i, j int) { pairs[i], pairs[j] = pairs[j], pairs[i] })
+	rand.Shuffle(len(singles), func(i, j int) { singles[i], singles[j] = singles[j], singles[i] })
+
+	for _, orbit := range append(pairs[:numPairs:numPairs], singles[:numSingles]...) {
+		for _, i := range orbit {
+			field[i/int(width)][i%int(width)].isBomb = true
 		}
 	}
 
@@ -104,25 +204,46 @@ func NewMinesweeper(width, height, numBombs int8) (error, *Minesweeper) {
 		}
 	}
 
-	return nil, &Minesweeper{field, int(width), int(height)}
+	return nil, &Minesweeper{field: field, width: int(width), height: int(height)}
 }
 
-// Get returns cell at position x, y
-func (ms Minesweeper) Get(x, y int) (error, *Cell) {
-	if x > ms.height || y > ms.width {
-		return errors.New("x or y is larger than a field size"), nil
+// Get returns a snapshot of the cell at position x, y.
+// The snapshot is a copy, so it won't change when further moves are applied
+func (ms *Minesweeper) Get(x, y int) (error, *Cell) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	if x < 0 || x >= ms.height || y < 0 || y >= ms.width {
+		return errors.New("x or y is outside of the field"), nil
 	}
-	return nil, &ms.field[x][y]
+	cell := ms.field[x][y]
+	return nil, &cell
+}
+
+// Snapshot returns a copy of the whole field taken under a single lock,
+// so it never mixes cells from before and after a move
+func (ms *Minesweeper) Snapshot() [][]Cell {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	field := make([][]Cell, len(ms.field))
+	for i := range ms.field {
+		field[i] = append([]Cell(nil), ms.field[i]...)
+	}
+	return field
 }
 
 // Uncover acts on a Cell at position x, y and returns if it's a bomb.
 // If cell is not a bomb, it's label is also updated to comtain the number of surronding bombs
 // Surrounding empty cells are uncovered automatically
 func (ms *Minesweeper) Uncover(x, y int) (error, bool) {
-	if x >= ms.height || y >= ms.width {
-		return errors.New("x or y is larger than a field size"), false
+	if x < 0 || x >= ms.width || y < 0 || y >= ms.height {
+		return errors.New("x or y is outside of the field"), false
 	}
 
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
 	cell := &ms.field[y][x]
 	if !cell.isBomb {
 		// uncover surrounding cells
@@ -174,9 +295,10 @@ func NewRenderer(ms *Minesweeper) (error, *Renderer) {
 
 // render draws minesweeper field on screen
 func (r Renderer) render() {
-	for i := 0; i < r.minesweeper.height; i++ {
-		for j := 0; j < r.minesweeper.width; j++ {
-			_, cell := r.minesweeper.Get(i, j)
+	field := r.minesweeper.Snapshot()
+	for i := range field {
+		for j := range field[i] {
+			cell := field[i][j]
 			if cell.isBomb && cell.uncovered {
 				r.screen.SetContent(j, i, 'x', nil, r.defStyle.Foreground(tcell.ColorRed))
 			} else if cell.uncovered {
@@ -275,7 +397,16 @@ func Min[T constraints.Ordered](a, b T) T {
 }
 
 func main() {
-	err, minesweeper := NewMinesweeper(8, 8, 10)
+	symmetryName := flag.String("symmetry", "none", "mine layout symmetry: none, horizontal, vertical or rotational")
+	flag.Parse()
+
+	err, symmetry := ParseSymmetry(*symmetryName)
+
+	if err != nil {
+		log.Fatalf("Invalid -symmetry: %s", err)
+	}
+
+	err, minesweeper := NewSymmetricMinesweeper(8, 8, 10, symmetry)
 
 	if err != nil {
 		log.Panicf("Error while creating minesweeper: %s", err)
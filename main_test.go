@@ -1,6 +1,9 @@
 package main
 
-import "testing"
+import (
+	"sync"
+	"testing"
+)
 
 func TestNewMinesweeper(t *testing.T) {
 	expectedNumBombs := int8(10)
@@ -23,3 +26,147 @@ func TestNewMinesweeper(t *testing.T) {
 		t.Errorf("%d bombs actual != %d bombs expected", actualNumBombs, expectedNumBombs)
 	}
 }
+
+func TestNewSymmetricMinesweeper(t *testing.T) {
+	symmetries := []Symmetry{SymmetryHorizontal, SymmetryVertical, SymmetryRotational}
+	width, height := 7, 9
+
+	for _, symmetry := range symmetries {
+		expectedNumBombs := int8(11)
+		err, minesweeper := NewSymmetricMinesweeper(int8(width), int8(height), expectedNumBombs, symmetry)
+
+		if err != nil {
+			t.Fatalf("Error while creating minesweeper with symmetry %d: %s", symmetry, err.Error())
+		}
+
+		actualNumBombs := int8(0)
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				mx, my := symmetry.mirror(x, y, width, height)
+				if minesweeper.field[y][x].isBomb != minesweeper.field[my][mx].isBomb {
+					t.Errorf("Symmetry %d broken at (%d, %d)", symmetry, x, y)
+				}
+				if minesweeper.field[y][x].isBomb {
+					actualNumBombs++
+				}
+			}
+		}
+
+		if actualNumBombs != expectedNumBombs {
+			t.Errorf("Symmetry %d: %d bombs actual != %d bombs expected", symmetry, actualNumBombs, expectedNumBombs)
+		}
+	}
+
+	// an 8x8 field has no centre cell, so an odd number of bombs can't be rotationally symmetric
+	if err, _ := NewSymmetricMinesweeper(8, 8, 11, SymmetryRotational); err == nil {
+		t.Errorf("Expected an error for an odd number of bombs with rotational symmetry on an even field")
+	}
+
+	if err, _ := NewSymmetricMinesweeper(8, 8, -1, SymmetryNone); err == nil {
+		t.Errorf("Expected an error for a negative number of bombs")
+	}
+}
+
+// checkSnapshot fails the test if field shows a half-finished flood fill:
+// a covered empty cell right next to an uncovered empty one
+func checkSnapshot(t *testing.T, field [][]Cell) {
+	isEmpty := func(c Cell) bool { return !c.isBomb && c.label == 0 }
+
+	for i := range field {
+		for j := range field[i] {
+			if !isEmpty(field[i][j]) || !field[i][j].uncovered {
+				continue
+			}
+			for ni := Max(0, i-1); ni < Min(len(field), i+2); ni++ {
+				for nj := Max(0, j-1); nj < Min(len(field[ni]), j+2); nj++ {
+					if isEmpty(field[ni][nj]) && !field[ni][nj].uncovered {
+						t.Errorf("Torn snapshot: (%d, %d) is uncovered but its empty neighbour (%d, %d) is not", j, i, nj, ni)
+					}
+				}
+			}
+		}
+	}
+}
+
+// TestConcurrentAccess is meant to be run with -race
+func TestConcurrentAccess(t *testing.T) {
+	// a bigger field makes flood fills long enough for readers to land in the middle of one
+	const size = 16
+	err, minesweeper := NewMinesweeper(size, size, 40)
+
+	if err != nil {
+		t.Fatalf("Error while creating minesweeper: %s", err.Error())
+	}
+
+	var wg sync.WaitGroup
+	for x := 0; x < size; x++ {
+		wg.Add(2)
+
+		go func(x int) {
+			defer wg.Done()
+			for y := 0; y < size; y++ {
+				if err, _ := minesweeper.Uncover(x, y); err != nil {
+					t.Errorf("Error while uncovering (%d, %d): %s", x, y, err.Error())
+				}
+			}
+		}(x)
+
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 50; n++ {
+				checkSnapshot(t, minesweeper.Snapshot())
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < size; i++ {
+		for j := 0; j < size; j++ {
+			if _, cell := minesweeper.Get(i, j); !cell.uncovered {
+				t.Errorf("Cell (%d, %d) is still covered after uncovering every cell", j, i)
+			}
+		}
+	}
+}
+
+func TestNewSymmetricMinesweeperMinesAxis(t *testing.T) {
+	// on a 3x3 field with horizontal symmetry the middle column holds 3 of the 9 cells,
+	// so with 2 bombs it must be mined in some of the generated layouts
+	for attempt := 0; attempt < 200; attempt++ {
+		err, minesweeper := NewSymmetricMinesweeper(3, 3, 2, SymmetryHorizontal)
+
+		if err != nil {
+			t.Fatalf("Error while creating minesweeper: %s", err.Error())
+		}
+
+		for y := 0; y < 3; y++ {
+			if minesweeper.field[y][1].isBomb {
+				return
+			}
+		}
+	}
+
+	t.Errorf("Middle column was never mined in 200 generated fields")
+}
+
+func TestOutOfBounds(t *testing.T) {
+	err, minesweeper := NewMinesweeper(8, 6, 10)
+
+	if err != nil {
+		t.Fatalf("Error while creating minesweeper: %s", err.Error())
+	}
+
+	// Get takes (row, col), Uncover takes (col, row)
+	for _, pos := range [][2]int{{-1, 0}, {0, -1}, {6, 0}, {0, 8}} {
+		if err, _ := minesweeper.Get(pos[0], pos[1]); err == nil {
+			t.Errorf("Expected an error from Get(%d, %d)", pos[0], pos[1])
+		}
+		if err, _ := minesweeper.Uncover(pos[1], pos[0]); err == nil {
+			t.Errorf("Expected an error from Uncover(%d, %d)", pos[1], pos[0])
+		}
+	}
+
+	if err, _ := minesweeper.Uncover(7, 5); err != nil {
+		t.Errorf("Error while uncovering the bottom right cell: %s", err.Error())
+	}
+}